@@ -0,0 +1,28 @@
+package detector
+
+import "regexp"
+
+var builtinPIIRegex = regexp.MustCompile(`(?i)(\b(?:[a-z]+\s)?(?:SSN|social security number|driver's license|passport|credit card|debit card|bank account)\b|\b(?:[a-z]+\s)?(?:first|last|middle|maiden|previous|current)\s?(?:name|initials)\b|\b(?:[a-z]+\s)?(?:phone|fax|email|address|city|state|zip|postal)\s?(?:number|code)\b)`)
+
+var builtinSPIIRegex = regexp.MustCompile(`(?i)(\b(?:[a-z]+\s)?(?:medical|health|insurance|benefits|prescription|treatment)\s?(?:information|record)\b|\b(?:[a-z]+\s)?(?:ethnicity|race|sexual|gender|religion)\s?(?:identity|orientation)\b)`)
+
+// NewBuiltinPII returns the tool's original PII detector, carried over
+// unchanged from the hardcoded piiRegex.
+func NewBuiltinPII() Detector {
+	return &regexDetector{name: "pii", category: CategoryPII, re: builtinPIIRegex, confidence: 1.0}
+}
+
+// NewBuiltinSPII returns the tool's original SPII detector, carried
+// over unchanged from the hardcoded spiiRegex.
+func NewBuiltinSPII() Detector {
+	return &regexDetector{name: "spii", category: CategorySPII, re: builtinSPIIRegex, confidence: 1.0}
+}
+
+// DefaultRegistry returns a Registry seeded with the two built-in
+// detectors.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(NewBuiltinPII())
+	r.Register(NewBuiltinSPII())
+	return r
+}
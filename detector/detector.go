@@ -0,0 +1,66 @@
+// Package detector provides the pluggable PII/SPII detection framework
+// used by the DLP tool: a common Detector interface, a registry that
+// user-supplied pattern packs can add to, and the built-in regex rules
+// that ship with the tool.
+package detector
+
+import "regexp"
+
+// Category classifies what kind of sensitive data a Detector looks for.
+type Category string
+
+const (
+	CategoryPII    Category = "pii"
+	CategorySPII   Category = "spii"
+	CategoryCustom Category = "custom"
+)
+
+// Match is one span a Detector found in a piece of input.
+type Match struct {
+	Detector   string
+	Category   Category
+	Value      []byte
+	Start      int
+	End        int
+	Confidence float64
+}
+
+// Detector finds sensitive spans in a byte slice.
+type Detector interface {
+	Name() string
+	Category() Category
+	Find(input []byte) []Match
+}
+
+// regexDetector is a Detector backed by a single compiled regexp, with
+// an optional validator to reject regex matches that don't check out
+// (e.g. a credit-card-shaped string that fails Luhn).
+type regexDetector struct {
+	name       string
+	category   Category
+	re         *regexp.Regexp
+	confidence float64
+	validate   func([]byte) bool
+}
+
+func (d *regexDetector) Name() string       { return d.name }
+func (d *regexDetector) Category() Category { return d.category }
+
+func (d *regexDetector) Find(input []byte) []Match {
+	var matches []Match
+	for _, loc := range d.re.FindAllIndex(input, -1) {
+		value := input[loc[0]:loc[1]]
+		if d.validate != nil && !d.validate(value) {
+			continue
+		}
+		matches = append(matches, Match{
+			Detector:   d.name,
+			Category:   d.category,
+			Value:      append([]byte(nil), value...),
+			Start:      loc[0],
+			End:        loc[1],
+			Confidence: d.confidence,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,69 @@
+package detector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// packEntry is one detector definition in a user-supplied pattern pack.
+type packEntry struct {
+	Name       string  `yaml:"name" json:"name"`
+	Category   string  `yaml:"category" json:"category"`
+	Regex      string  `yaml:"regex" json:"regex"`
+	Confidence float64 `yaml:"confidence" json:"confidence"`
+	Validator  string  `yaml:"validator" json:"validator"`
+}
+
+// pack is the on-disk shape of a --rules pattern pack file.
+type pack struct {
+	Detectors []packEntry `yaml:"detectors" json:"detectors"`
+}
+
+// LoadPack reads a YAML or JSON pattern pack and compiles it into
+// Detectors. Entries in the "custom" category are namespaced as
+// "custom.<name>" so they can't collide with built-in detector names.
+func LoadPack(path string) ([]Detector, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pattern pack %s: %w", path, err)
+	}
+
+	var p pack
+	if err := yaml.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("parse pattern pack %s: %w", path, err)
+	}
+
+	detectors := make([]Detector, 0, len(p.Detectors))
+	for _, entry := range p.Detectors {
+		re, err := regexp.Compile(entry.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("pattern pack %s: detector %q: %w", path, entry.Name, err)
+		}
+		validate, err := resolveValidator(entry.Validator)
+		if err != nil {
+			return nil, fmt.Errorf("pattern pack %s: detector %q: %w", path, entry.Name, err)
+		}
+
+		category := Category(entry.Category)
+		confidence := entry.Confidence
+		if confidence == 0 {
+			confidence = 1.0
+		}
+		name := entry.Name
+		if category == CategoryCustom {
+			name = "custom." + entry.Name
+		}
+
+		detectors = append(detectors, &regexDetector{
+			name:       name,
+			category:   category,
+			re:         re,
+			confidence: confidence,
+			validate:   validate,
+		})
+	}
+	return detectors, nil
+}
@@ -0,0 +1,56 @@
+package detector
+
+import "sync"
+
+// Registry holds the set of Detectors active for a run, in registration
+// order, and lets callers scope a run down to a subset by name via
+// Scoped (e.g. --detectors=ssn,email,custom.gdpr_de).
+type Registry struct {
+	mu        sync.Mutex
+	detectors map[string]Detector
+	order     []string
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{detectors: make(map[string]Detector)}
+}
+
+// Register adds d to the registry, or replaces the existing detector of
+// the same name.
+func (r *Registry) Register(d Detector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.detectors[d.Name()]; !exists {
+		r.order = append(r.order, d.Name())
+	}
+	r.detectors[d.Name()] = d
+}
+
+// All returns every registered detector, in registration order.
+func (r *Registry) All() []Detector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Detector, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.detectors[name])
+	}
+	return out
+}
+
+// Scoped returns the registered detectors named in names, in the order
+// names was given. An empty names returns every registered detector.
+func (r *Registry) Scoped(names []string) []Detector {
+	if len(names) == 0 {
+		return r.All()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Detector, 0, len(names))
+	for _, name := range names {
+		if d, ok := r.detectors[name]; ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package detector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// resolveValidator parses a pattern pack's "validator" field into a
+// check function run against a detector's raw regex match. An empty
+// spec means "no validation, the regex match is enough".
+func resolveValidator(spec string) (func([]byte) bool, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == "luhn":
+		return luhnValid, nil
+	case spec == "mod11":
+		return mod11Valid, nil
+	case strings.HasPrefix(spec, "length:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(spec, "length:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid length validator %q: %w", spec, err)
+		}
+		return func(value []byte) bool { return len(digitsOf(value)) == n }, nil
+	default:
+		return nil, fmt.Errorf("unknown validator %q", spec)
+	}
+}
+
+// luhnValid checks the Luhn checksum used by credit card numbers.
+func luhnValid(value []byte) bool {
+	digits := digitsOf(value)
+	if len(digits) == 0 {
+		return false
+	}
+	sum := 0
+	parity := len(digits) % 2
+	for i, d := range digits {
+		n := int(d - '0')
+		if i%2 == parity {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+// mod11Valid checks a simple mod-11 weighted checksum, as used by some
+// national ID number formats.
+func mod11Valid(value []byte) bool {
+	digits := digitsOf(value)
+	if len(digits) == 0 {
+		return false
+	}
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 7 {
+			weight = 2
+		}
+	}
+	return sum%11 == 0
+}
+
+func digitsOf(value []byte) []byte {
+	digits := make([]byte, 0, len(value))
+	for _, b := range value {
+		if b >= '0' && b <= '9' {
+			digits = append(digits, b)
+		}
+	}
+	return digits
+}
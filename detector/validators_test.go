@@ -0,0 +1,65 @@
+package detector
+
+import "testing"
+
+func TestLuhnValid(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"valid visa", "4111111111111111", true},
+		{"bad checksum", "4111111111111112", false},
+		{"dashes are ignored", "4111-1111-1111-1111", true},
+		{"no digits", "not-a-card", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := luhnValid([]byte(c.value)); got != c.want {
+				t.Errorf("luhnValid(%q) = %v, want %v", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMod11Valid(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{"valid checksum", "000000014", true},
+		{"bad checksum", "000000015", false},
+		{"punctuation is ignored", "00-0000-014", true},
+		{"no digits", "not-an-id", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := mod11Valid([]byte(c.value)); got != c.want {
+				t.Errorf("mod11Valid(%q) = %v, want %v", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolveValidator(t *testing.T) {
+	if v, err := resolveValidator(""); v != nil || err != nil {
+		t.Errorf("resolveValidator(\"\") = %v, %v, want nil, nil", v != nil, err)
+	}
+	if v, err := resolveValidator("luhn"); err != nil || v == nil {
+		t.Errorf("resolveValidator(\"luhn\") = %v, %v, want non-nil, nil", v != nil, err)
+	}
+	v, err := resolveValidator("length:9")
+	if err != nil || v == nil {
+		t.Fatalf("resolveValidator(\"length:9\") = %v, %v, want non-nil, nil", v != nil, err)
+	}
+	if !v([]byte("123456789")) {
+		t.Error("length:9 validator rejected a 9-digit value")
+	}
+	if v(([]byte("12345678"))) {
+		t.Error("length:9 validator accepted an 8-digit value")
+	}
+	if _, err := resolveValidator("bogus"); err == nil {
+		t.Error("resolveValidator(\"bogus\") = nil error, want an error")
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rscrim/data-redact/detector"
+)
+
+// transformFunc turns a value that matched a detector (or a key-name
+// policy) into its replacement bytes: a vault token, or the literal
+// "[redacted]" marker.
+type transformFunc func(value []byte) []byte
+
+// FormatOptions configures the structured redactors.
+type FormatOptions struct {
+	KeyNames   []string // JSON keys that are always redacted, regardless of value
+	CSVColumns []string // CSV columns (by header name or 0-based index) that are always redacted
+	XMLPaths   []string // XPath-like glob patterns (e.g. "/record/ssn") that are always redacted
+}
+
+// defaultKeyNames is the built-in JSON key-name policy used when the
+// caller doesn't supply one.
+var defaultKeyNames = []string{"ssn", "email", "dob", "phone", "address", "passport", "credit_card", "bank_account"}
+
+// formatAwareTransform routes to the structured redactor implied by
+// file's extension, or calls fallback for anything else (the original
+// byte-regex path).
+func formatAwareTransform(file string, input []byte, detectors []detector.Detector, opts FormatOptions, transform transformFunc, fallback func() []byte) ([]byte, []string, error) {
+	keyNames := opts.KeyNames
+	if len(keyNames) == 0 {
+		keyNames = defaultKeyNames
+	}
+
+	switch formatFor(file) {
+	case "json":
+		return redactJSON(input, keyNames, detectors, transform)
+	case "csv":
+		return redactCSV(input, opts.CSVColumns, detectors, transform)
+	case "xml":
+		return redactXML(input, opts.XMLPaths, detectors, transform)
+	default:
+		return fallback(), nil, nil
+	}
+}
+
+// formatAwareDetokenize routes to the structured detokenizer implied by
+// file's extension, or calls fallback for anything else (the original
+// byte-regex path). Unlike formatAwareTransform, detokenizing doesn't
+// need detector matching or a key-name policy: a token is
+// self-identifying, so every string scalar/cell/element is just run
+// through tokenRegex and any match is swapped back in through the
+// format's own encoder, which is what keeps quoting/escaping intact.
+func formatAwareDetokenize(file string, input []byte, vault *Vault, fallback func() []byte) ([]byte, error) {
+	switch formatFor(file) {
+	case "json":
+		return detokenizeJSON(input, vault)
+	case "csv":
+		return detokenizeCSV(input, vault)
+	case "xml":
+		return detokenizeXML(input, vault)
+	default:
+		return fallback(), nil
+	}
+}
+
+// detokenizeValue swaps every vault token found in value back to its
+// recorded plaintext, leaving unrecognized tokens untouched.
+func detokenizeValue(value []byte, vault *Vault) []byte {
+	return tokenRegex.ReplaceAllFunc(value, func(match []byte) []byte {
+		if original, ok := vault.Original(string(match)); ok {
+			return []byte(original)
+		}
+		return match
+	})
+}
+
+// detokenizeJSON walks a JSON document token by token, swapping tokens
+// back to their original plaintext in every string scalar. Walking
+// json.Decoder tokens directly (rather than round-tripping through a
+// map[string]interface{}) keeps object key order stable.
+func detokenizeJSON(input []byte, vault *Vault) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var out bytes.Buffer
+
+	var walk func() error
+	walk = func() error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				out.WriteByte('{')
+				first := true
+				for dec.More() {
+					if !first {
+						out.WriteByte(',')
+					}
+					first = false
+
+					keyTok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					key, _ := keyTok.(string)
+					keyJSON, err := json.Marshal(key)
+					if err != nil {
+						return err
+					}
+					out.Write(keyJSON)
+					out.WriteByte(':')
+
+					if err := walk(); err != nil {
+						return err
+					}
+				}
+				if _, err := dec.Token(); err != nil { // consume closing '}'
+					return err
+				}
+				out.WriteByte('}')
+			case '[':
+				out.WriteByte('[')
+				first := true
+				for dec.More() {
+					if !first {
+						out.WriteByte(',')
+					}
+					first = false
+					if err := walk(); err != nil {
+						return err
+					}
+				}
+				if _, err := dec.Token(); err != nil { // consume closing ']'
+					return err
+				}
+				out.WriteByte(']')
+			}
+			return nil
+		case string:
+			b, err := json.Marshal(string(detokenizeValue([]byte(t), vault)))
+			if err != nil {
+				return err
+			}
+			out.Write(b)
+			return nil
+		default:
+			b, err := json.Marshal(tok)
+			if err != nil {
+				return err
+			}
+			out.Write(b)
+			return nil
+		}
+	}
+
+	if err := walk(); err != nil {
+		return nil, fmt.Errorf("detokenize JSON: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// detokenizeCSV swaps tokens back to their original plaintext in every
+// cell of a CSV document.
+func detokenizeCSV(input []byte, vault *Vault) ([]byte, error) {
+	reader := csv.NewReader(bytes.NewReader(input))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("detokenize CSV: %w", err)
+	}
+
+	for _, row := range records {
+		for i, cell := range row {
+			row[i] = string(detokenizeValue([]byte(cell), vault))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return nil, fmt.Errorf("detokenize CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detokenizeXML streams an XML document, swapping tokens back to their
+// original plaintext in element text and attribute values.
+func detokenizeXML(input []byte, vault *Vault) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(input))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("detokenize XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for i, attr := range t.Attr {
+				t.Attr[i].Value = string(detokenizeValue([]byte(attr.Value), vault))
+			}
+			if err := enc.EncodeToken(t); err != nil {
+				return nil, err
+			}
+		case xml.CharData:
+			if err := enc.EncodeToken(xml.CharData(detokenizeValue(t, vault))); err != nil {
+				return nil, err
+			}
+		default:
+			if err := enc.EncodeToken(tok); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, fmt.Errorf("detokenize XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatFor returns the structured format implied by file's extension,
+// or "" to fall back to the byte-regex path.
+func formatFor(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	case ".xml":
+		return "xml"
+	default:
+		return ""
+	}
+}
+
+// matchesAnyDetector reports whether value is flagged by any of detectors.
+func matchesAnyDetector(value []byte, detectors []detector.Detector) bool {
+	for _, d := range detectors {
+		if len(d.Find(value)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON walks a JSON document token by token, redacting values
+// whose key matches keyNames or whose value matches a detector. Walking
+// json.Decoder tokens directly (rather than round-tripping through a
+// map[string]interface{}) keeps object key order stable.
+func redactJSON(input []byte, keyNames []string, detectors []detector.Detector, transform transformFunc) ([]byte, []string, error) {
+	wanted := make(map[string]bool, len(keyNames))
+	for _, name := range keyNames {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	var out bytes.Buffer
+	var touched []string
+
+	var walk func(path string, forceRedact bool) error
+	walk = func(path string, forceRedact bool) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				out.WriteByte('{')
+				first := true
+				for dec.More() {
+					if !first {
+						out.WriteByte(',')
+					}
+					first = false
+
+					keyTok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					key, _ := keyTok.(string)
+					keyJSON, err := json.Marshal(key)
+					if err != nil {
+						return err
+					}
+					out.Write(keyJSON)
+					out.WriteByte(':')
+
+					if err := walk(path+"."+key, forceRedact || wanted[strings.ToLower(key)]); err != nil {
+						return err
+					}
+				}
+				if _, err := dec.Token(); err != nil { // consume closing '}'
+					return err
+				}
+				out.WriteByte('}')
+			case '[':
+				out.WriteByte('[')
+				first := true
+				for i := 0; dec.More(); i++ {
+					if !first {
+						out.WriteByte(',')
+					}
+					first = false
+					if err := walk(fmt.Sprintf("%s[%d]", path, i), forceRedact); err != nil {
+						return err
+					}
+				}
+				if _, err := dec.Token(); err != nil { // consume closing ']'
+					return err
+				}
+				out.WriteByte(']')
+			}
+			return nil
+		case string:
+			value := t
+			if forceRedact || matchesAnyDetector([]byte(value), detectors) {
+				touched = append(touched, path)
+				value = string(transform([]byte(value)))
+			}
+			b, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			out.Write(b)
+			return nil
+		default:
+			if forceRedact && tok != nil {
+				touched = append(touched, path)
+				raw := fmt.Sprint(tok)
+				if num, ok := tok.(json.Number); ok {
+					raw = num.String()
+				}
+				b, err := json.Marshal(string(transform([]byte(raw))))
+				if err != nil {
+					return err
+				}
+				out.Write(b)
+				return nil
+			}
+			b, err := json.Marshal(tok)
+			if err != nil {
+				return err
+			}
+			out.Write(b)
+			return nil
+		}
+	}
+
+	if err := walk("$", false); err != nil {
+		return nil, nil, fmt.Errorf("redact JSON: %w", err)
+	}
+	return out.Bytes(), touched, nil
+}
+
+// redactCSV redacts cells in columns (by header name or 0-based index)
+// plus any cell whose value matches a detector.
+func redactCSV(input []byte, columns []string, detectors []detector.Detector, transform transformFunc) ([]byte, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(input))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("redact CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return input, nil, nil
+	}
+
+	header := records[0]
+	targets := make(map[int]bool)
+	for i, col := range header {
+		for _, want := range columns {
+			if strings.EqualFold(col, want) {
+				targets[i] = true
+			}
+		}
+	}
+	for _, want := range columns {
+		if idx, err := strconv.Atoi(want); err == nil && idx >= 0 && idx < len(header) {
+			targets[idx] = true
+		}
+	}
+
+	var touched []string
+	for r := 1; r < len(records); r++ {
+		for i, cell := range records[r] {
+			if !targets[i] && !matchesAnyDetector([]byte(cell), detectors) {
+				continue
+			}
+			records[r][i] = string(transform([]byte(cell)))
+			touched = append(touched, fmt.Sprintf("row %d, column %q", r, header[i]))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return nil, nil, fmt.Errorf("redact CSV: %w", err)
+	}
+	return buf.Bytes(), touched, nil
+}
+
+// redactXML streams an XML document, redacting element text and
+// attribute values whose path matches an XPath-like glob in pathGlobs
+// or whose value matches a detector.
+func redactXML(input []byte, pathGlobs []string, detectors []detector.Detector, transform transformFunc) ([]byte, []string, error) {
+	matchesPath := func(path string) bool {
+		for _, pattern := range pathGlobs {
+			if ok, _ := filepath.Match(pattern, path); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(input))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	var touched []string
+	var elementPath []string
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("redact XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			elementPath = append(elementPath, t.Name.Local)
+			path := "/" + strings.Join(elementPath, "/")
+			for i, attr := range t.Attr {
+				attrPath := path + "/@" + attr.Name.Local
+				if matchesPath(attrPath) || matchesAnyDetector([]byte(attr.Value), detectors) {
+					t.Attr[i].Value = string(transform([]byte(attr.Value)))
+					touched = append(touched, attrPath)
+				}
+			}
+			if err := enc.EncodeToken(t); err != nil {
+				return nil, nil, err
+			}
+		case xml.EndElement:
+			if err := enc.EncodeToken(t); err != nil {
+				return nil, nil, err
+			}
+			if len(elementPath) > 0 {
+				elementPath = elementPath[:len(elementPath)-1]
+			}
+		case xml.CharData:
+			path := "/" + strings.Join(elementPath, "/")
+			text := string(t)
+			if strings.TrimSpace(text) != "" && (matchesPath(path) || matchesAnyDetector(t, detectors)) {
+				touched = append(touched, path)
+				if err := enc.EncodeToken(xml.CharData(transform(t))); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			if err := enc.EncodeToken(t); err != nil {
+				return nil, nil, err
+			}
+		default:
+			if err := enc.EncodeToken(tok); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, nil, fmt.Errorf("redact XML: %w", err)
+	}
+	return buf.Bytes(), touched, nil
+}
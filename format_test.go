@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRedactJSONKeyNamePropagatesIntoNestedObjects(t *testing.T) {
+	input := []byte(`{"ssn":"123-45-6789","address":{"street":"123 Main St","city":"Springfield","zip":"12345"}}`)
+	transform := func([]byte) []byte { return []byte("[redacted]") }
+
+	out, touched, err := redactJSON(input, []string{"ssn", "address"}, nil, transform)
+	if err != nil {
+		t.Fatalf("redactJSON: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+
+	address, ok := got["address"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("address is not an object: %#v", got["address"])
+	}
+	for _, field := range []string{"street", "city", "zip"} {
+		if address[field] != "[redacted]" {
+			t.Errorf("address.%s = %v, want [redacted]", field, address[field])
+		}
+	}
+	if got["ssn"] != "[redacted]" {
+		t.Errorf("ssn = %v, want [redacted]", got["ssn"])
+	}
+
+	wantTouched := []string{"$.ssn", "$.address.street", "$.address.city", "$.address.zip"}
+	if !reflect.DeepEqual(touched, wantTouched) {
+		t.Errorf("touched = %v, want %v", touched, wantTouched)
+	}
+}
+
+func TestRedactJSONNumericForceRedactPreservesDigits(t *testing.T) {
+	input := []byte(`{"dob":19900101}`)
+	var captured string
+	transform := func(v []byte) []byte {
+		captured = string(v)
+		return []byte("[redacted]")
+	}
+
+	out, _, err := redactJSON(input, []string{"dob"}, nil, transform)
+	if err != nil {
+		t.Fatalf("redactJSON: %v", err)
+	}
+	if captured != "19900101" {
+		t.Errorf("transform was called with %q, want %q (not scientific notation)", captured, "19900101")
+	}
+	if want := `{"dob":"[redacted]"}`; string(out) != want {
+		t.Errorf("out = %s, want %s", out, want)
+	}
+}
+
+func TestDetokenizeJSONRoundTrip(t *testing.T) {
+	v := NewVault(filepath.Join(t.TempDir(), "vault.dat"), "hunter2")
+	if err := v.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	original := `She said "hello" and left.`
+	token := v.TokenFor("a.json", []byte(original))
+
+	input, err := json.Marshal(map[string]string{"note": token})
+	if err != nil {
+		t.Fatalf("marshal input: %v", err)
+	}
+
+	out, err := detokenizeJSON(input, v)
+	if err != nil {
+		t.Fatalf("detokenizeJSON: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	if got["note"] != original {
+		t.Errorf("note = %q, want %q", got["note"], original)
+	}
+}
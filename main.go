@@ -9,27 +9,64 @@ Version: 1.0
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
-)
-
-var piiRegex = regexp.MustCompile(`(?i)(\b(?:[a-z]+\s)?(?:SSN|social security number|driver's license|passport|credit card|debit card|bank account)\b|\b(?:[a-z]+\s)?(?:first|last|middle|maiden|previous|current)\s?(?:name|initials)\b|\b(?:[a-z]+\s)?(?:phone|fax|email|address|city|state|zip|postal)\s?(?:number|code)\b)`)
 
-var spiiRegex = regexp.MustCompile(`(?i)(\b(?:[a-z]+\s)?(?:medical|health|insurance|benefits|prescription|treatment)\s?(?:information|record)\b|\b(?:[a-z]+\s)?(?:ethnicity|race|sexual|gender|religion)\s?(?:identity|orientation)\b)`)
+	"github.com/rscrim/data-redact/detector"
+)
 
 func main() {
 	// Define command line options
 	modePtr := flag.String("mode", "tokenize", "Specify the DLP mode: tokenize, detokenize or redact")
 	filePtr := flag.String("file", "", "Specify the file or directory path")
 	outputPtr := flag.String("output", "", "Specify the output directory path")
-	tokenPtr := flag.String("token", "[TOKEN]", "Specify the token used for tokenization")
+	vaultPtr := flag.String("vault", "vault.dat", "Specify the path to the tokenization vault sidecar file")
+	passphrasePtr := flag.String("passphrase", "", "Specify the passphrase used to encrypt/decrypt the vault")
+	workersPtr := flag.Int("workers", runtime.NumCPU(), "Specify the number of concurrent workers used to process files")
+	yesPtr := flag.Bool("yes", false, "Bypass the interactive per-file confirmation prompt")
+	includePtr := flag.String("include", "", "Comma-separated glob patterns of files to include (default: all)")
+	excludePtr := flag.String("exclude", "", "Comma-separated glob patterns of files to exclude")
+	policyPtr := flag.String("policy", "", "Specify a YAML policy file of allow/deny path rules (default: built-in deny list)")
+	followSymlinksPtr := flag.Bool("follow-symlinks", false, "Follow symlinks during traversal instead of skipping them")
+	rulesPtr := flag.String("rules", "", "Specify a YAML/JSON pattern pack of additional detectors to load")
+	detectorsPtr := flag.String("detectors", "", "Comma-separated detector names to scope the run to (default: all registered)")
+	jsonKeysPtr := flag.String("json-keys", "", "Comma-separated JSON key names to always redact (default: a built-in PII key list)")
+	csvColumnsPtr := flag.String("csv-columns", "", "Comma-separated CSV column names or indices to always redact")
+	xmlPathsPtr := flag.String("xml-paths", "", "Comma-separated XPath-like glob patterns of XML elements/attributes to always redact")
 	flag.Parse()
 
+	registry := detector.DefaultRegistry()
+	if *rulesPtr != "" {
+		pack, err := detector.LoadPack(*rulesPtr)
+		if err != nil {
+			fmt.Printf("Error: Could not load pattern pack %s: %v\n", *rulesPtr, err)
+			os.Exit(1)
+		}
+		for _, d := range pack {
+			registry.Register(d)
+		}
+	}
+	detectors := registry.Scoped(splitCommaList(*detectorsPtr))
+
+	var vault *Vault
+	if *modePtr == "tokenize" || *modePtr == "detokenize" {
+		if *passphrasePtr == "" {
+			fmt.Println("Error: --passphrase is required for tokenize/detokenize modes")
+			os.Exit(1)
+		}
+		vault = NewVault(*vaultPtr, *passphrasePtr)
+		if err := vault.Load(); err != nil {
+			fmt.Printf("Error: Could not load vault %s: %v\n", *vaultPtr, err)
+			os.Exit(1)
+		}
+	}
+
 	// Check if file or directory is specified
 	if *filePtr == "" {
 		fmt.Println("Error: File or directory path is required")
@@ -37,111 +74,111 @@ func main() {
 	}
 
 	// Check if file or directory exists
-	fileInfo, err := os.Stat(*filePtr)
-	if err != nil {
+	if _, err := os.Stat(*filePtr); err != nil {
 		fmt.Println("Error: Could not access file or directory")
 		os.Exit(1)
 	}
 
-	// Check if directory is a known top-level folder or system directory
-	if isIllegalDirectory(fileInfo) {
-		fmt.Println("Error: Illegal directory selected")
+	// Resolve symlinks in the user-supplied path before any policy check,
+	// since filepath.Abs on a symlink would check the wrong location.
+	resolvedPath, err := filepath.EvalSymlinks(*filePtr)
+	if err != nil {
+		fmt.Printf("Error: Could not resolve %s: %v\n", *filePtr, err)
+		os.Exit(1)
+	}
+	absPath, err := filepath.Abs(resolvedPath)
+	if err != nil {
+		fmt.Printf("Error: Could not resolve %s: %v\n", *filePtr, err)
 		os.Exit(1)
 	}
 
-	// Process file or directory
-	var files []string
-	if fileInfo.IsDir() {
-		files, err = listFiles(*filePtr)
+	policy := defaultPolicy()
+	if *policyPtr != "" {
+		loaded, err := LoadPolicy(*policyPtr)
 		if err != nil {
-			fmt.Println("Error: Could not access directory contents")
+			fmt.Printf("Error: Could not load policy %s: %v\n", *policyPtr, err)
 			os.Exit(1)
 		}
-		if len(files) > 20 {
-			fmt.Printf("Found %d files in directory. Do you want to process all of them? (y/n): ", len(files))
-			var input string
-			fmt.Scanln(&input)
-			if strings.ToLower(input) != "y" {
-				for _, file := range files {
-					processFile(file, modePtr, tokenPtr, outputPtr)
-				}
-				return
-			}
-		}
-		for _, file := range files {
-			if !approveFile(file) {
-				continue
-			}
-			processFile(file, modePtr, tokenPtr, outputPtr)
-		}
-	} else {
-		if approveFile(*filePtr) {
-			processFile(*filePtr, modePtr, tokenPtr, outputPtr)
-		}
+		policy = loaded
+	}
+	if allowed, pattern := policy.Evaluate(absPath); !allowed {
+		fmt.Printf("Error: %s is denied by policy rule %q\n", absPath, pattern)
+		os.Exit(1)
 	}
-}
 
-// Tokenize input using the specified token
-func tokenize(input []byte, token string) []byte {
-	regex := regexp.MustCompile(`\b(\w+)\b`)
-	return regex.ReplaceAllFunc(input, func(match []byte) []byte {
-		return []byte(token)
+	// Recursively walk the target (a single file walks trivially) and
+	// process matching files across a pool of worker goroutines.
+	summary, err := scan(resolvedPath, ScanOptions{
+		ModePtr:        modePtr,
+		OutputPtr:      outputPtr,
+		Vault:          vault,
+		Workers:        *workersPtr,
+		Includes:       splitCommaList(*includePtr),
+		Excludes:       splitCommaList(*excludePtr),
+		Yes:            *yesPtr,
+		Policy:         policy,
+		FollowSymlinks: *followSymlinksPtr,
+		Detectors:      detectors,
+		Format: FormatOptions{
+			KeyNames:   splitCommaList(*jsonKeysPtr),
+			CSVColumns: splitCommaList(*csvColumnsPtr),
+			XMLPaths:   splitCommaList(*xmlPathsPtr),
+		},
 	})
+	if err != nil {
+		fmt.Printf("Error: Could not walk %s: %v\n", resolvedPath, err)
+		os.Exit(1)
+	}
+
+	saveVault(vault, *vaultPtr)
+
+	report, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: Could not marshal scan summary: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(report))
 }
 
-// Detokenize input using the specified token
-func detokenize(input []byte, token string) []byte {
-	regex := regexp.MustCompile(fmt.Sprintf(`\b%s\b`, regexp.QuoteMeta(token)))
-	return regex.ReplaceAllFunc(input, func(match []byte) []byte {
-		return []byte(strings.TrimSpace(string(match)))
-	})
+// saveVault persists the vault's token mappings, if a vault is in use.
+func saveVault(vault *Vault, vaultPath string) {
+	if vault == nil {
+		return
+	}
+	if err := vault.Save(); err != nil {
+		fmt.Printf("Error: Could not save vault %s: %v\n", vaultPath, err)
+		os.Exit(1)
+	}
 }
 
-// Redact PII and SPII information from input
-func redact(input []byte) []byte {
-	piiRegex := regexp.MustCompile(`(?i)\b(?:[a-z]+\s)?(?:SSN|social security number|driver's license|passport|credit card|debit card|bank account)\b`)
-	spiiRegex := regexp.MustCompile(`(?i)\b(?:[a-z]+\s)?(?:medical|health|insurance|benefits|prescription|treatment)\s?(?:information|record)\b`)
-	input = piiRegex.ReplaceAll(input, []byte("[redacted]"))
-	input = spiiRegex.ReplaceAll(input, []byte("[redacted]"))
-	return input
+// Tokenize replaces each detector match in input with a stable,
+// reversible token minted by vault. filePath keys the vault's per-file
+// radix index so re-running tokenize over the same file is idempotent.
+func tokenize(filePath string, input []byte, vault *Vault, matches []detector.Match) []byte {
+	return applySpans(input, matches, func(m detector.Match) []byte {
+		return []byte(vault.TokenFor(filePath, m.Value))
+	})
 }
 
-// Check if the directory is a known top-level folder or system directory
-func isIllegalDirectory(fileInfo os.FileInfo) bool {
-	if fileInfo.IsDir() {
-		absPath, err := filepath.Abs(fileInfo.Name())
-		if err == nil {
-			illegalDirs := []string{
-				"/etc",
-				"/var",
-				"C:/Program Files",
-				"C:/Program Files (x86)",
-				"C:/Windows",
-				"C:/Windows/System32",
-			}
-			for _, dir := range illegalDirs {
-				if strings.HasPrefix(absPath, dir) {
-					return true
-				}
-			}
+// tokenRegex matches the vault's [TKN-xxxxxxxx] token format.
+var tokenRegex = regexp.MustCompile(`\[TKN-[0-9a-f]+\]`)
+
+// Detokenize substitutes every token in input with the original value
+// recorded for it in vault, leaving unrecognized tokens untouched.
+func detokenize(input []byte, vault *Vault) []byte {
+	return tokenRegex.ReplaceAllFunc(input, func(match []byte) []byte {
+		if original, ok := vault.Original(string(match)); ok {
+			return []byte(original)
 		}
-	}
-	return false
+		return match
+	})
 }
 
-// List all files in the directory
-func listFiles(directory string) ([]string, error) {
-	var files []string
-	fileInfos, err := ioutil.ReadDir(directory)
-	if err != nil {
-		return nil, err
-	}
-	for _, fileInfo := range fileInfos {
-		if !fileInfo.IsDir() {
-			files = append(files, filepath.Join(directory, fileInfo.Name()))
-		}
-	}
-	return files, nil
+// Redact blanks out every detector match in input.
+func redact(input []byte, matches []detector.Match) []byte {
+	return applySpans(input, matches, func(detector.Match) []byte {
+		return []byte("[redacted]")
+	})
 }
 
 // Prompt the user to approve a file for processing
@@ -152,32 +189,74 @@ func approveFile(file string) bool {
 	return strings.ToLower(input) == "y"
 }
 
-// Process a file based on the selected mode
-func processFile(file string, modePtr *string, tokenPtr *string, outputPtr *string) {
+// Process a file based on the selected mode. The returned FileResult is
+// aggregated by scan into the run's overall summary, so every early
+// return must still carry a populated result.
+func processFile(file string, modePtr *string, vault *Vault, outputPtr *string, detectors []detector.Detector, formatOpts FormatOptions) *FileResult {
+	result := &FileResult{Path: file, Mode: *modePtr}
+
 	// Read input file
 	inputFile, err := os.ReadFile(file)
 	if err != nil {
 		fmt.Printf("Error: Could not read input file %s\n", file)
-		return
+		result.Error = err.Error()
+		return result
 	}
+	result.BytesScanned = len(inputFile)
 
-	// Identify PII and SPII in the input
-	piiMatches := piiRegex.FindAll(inputFile, -1)
-	spiiMatches := spiiRegex.FindAll(inputFile, -1)
-	fmt.Printf("Processing %s... Found %d PII matches and %d SPII matches\n", file, len(piiMatches), len(spiiMatches))
+	// Run every scoped detector over the input and tally hits per
+	// detector name and category.
+	matches := collectMatches(inputFile, detectors)
+	hits := make(map[string]*DetectorHit)
+	for _, m := range matches {
+		hit, ok := hits[m.Detector]
+		if !ok {
+			hit = &DetectorHit{Detector: m.Detector, Category: string(m.Category)}
+			hits[m.Detector] = hit
+		}
+		hit.Count++
+		switch m.Category {
+		case detector.CategoryPII:
+			result.PIIHits++
+		case detector.CategorySPII:
+			result.SPIIHits++
+		}
+	}
+	for _, d := range detectors {
+		if hit, ok := hits[d.Name()]; ok {
+			result.Detectors = append(result.Detectors, *hit)
+		}
+	}
+	fmt.Printf("Processing %s... Found %d PII matches and %d SPII matches\n", file, result.PIIHits, result.SPIIHits)
 
-	// Perform DLP based on the selected mode
+	// Perform DLP based on the selected mode. JSON/CSV/XML files are
+	// routed to a structured redactor instead of the byte-regex path, so
+	// quoting/escaping in those formats survives the round trip.
 	var output []byte
 	switch *modePtr {
 	case "tokenize":
-		output = tokenize(inputFile, *tokenPtr)
+		transform := func(value []byte) []byte { return []byte(vault.TokenFor(file, value)) }
+		output, result.TouchedFields, err = formatAwareTransform(file, inputFile, detectors, formatOpts, transform, func() []byte {
+			return tokenize(file, inputFile, vault, matches)
+		})
 	case "detokenize":
-		output = detokenize(inputFile, *tokenPtr)
+		output, err = formatAwareDetokenize(file, inputFile, vault, func() []byte {
+			return detokenize(inputFile, vault)
+		})
 	case "redact":
-		output = redact(inputFile)
+		transform := func([]byte) []byte { return []byte("[redacted]") }
+		output, result.TouchedFields, err = formatAwareTransform(file, inputFile, detectors, formatOpts, transform, func() []byte {
+			return redact(inputFile, matches)
+		})
 	default:
 		fmt.Printf("Error: Unknown mode %s\n", *modePtr)
-		return
+		result.Error = fmt.Sprintf("unknown mode %s", *modePtr)
+		return result
+	}
+	if err != nil {
+		fmt.Printf("Error: Could not redact %s: %v\n", file, err)
+		result.Error = err.Error()
+		return result
 	}
 
 	// Write output file
@@ -192,8 +271,11 @@ func processFile(file string, modePtr *string, tokenPtr *string, outputPtr *stri
 	}
 	if err := os.WriteFile(outputFile, output, 0644); err != nil {
 		fmt.Printf("Error: Could not write output file %s\n", outputFile)
-		return
+		result.Error = err.Error()
+		return result
 	}
 
 	fmt.Printf("Processed %s, output saved to %s\n", file, outputFile)
+	result.OutputFile = outputFile
+	return result
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyRule is one allow/deny rule evaluated against a resolved,
+// absolute path. A pattern ending in "*" is treated as a path prefix;
+// anything else is matched with filepath.Match.
+type PolicyRule struct {
+	Action  string `yaml:"action"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Policy is an ordered allow/deny rule set. The first rule whose
+// pattern matches a path decides it; if nothing matches, the path is
+// allowed by default.
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// SkipRecord is an audit entry for a path the traversal did not scan,
+// and why.
+type SkipRecord struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// defaultPolicy returns the built-in deny rules used when no --policy
+// file is supplied, covering the usual system and credential paths on
+// both POSIX and Windows hosts.
+func defaultPolicy() *Policy {
+	denied := []string{
+		"/proc", "/sys", "/dev",
+		"/etc", "/var",
+		"C:/Program Files", "C:/Program Files (x86)",
+		"C:/Windows", "C:/Windows/System32",
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		denied = append(denied, filepath.Join(home, ".ssh"))
+	}
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		denied = append(denied, appData)
+	}
+
+	policy := &Policy{}
+	for _, dir := range denied {
+		policy.Rules = append(policy.Rules, PolicyRule{Action: "deny", Pattern: filepath.FromSlash(dir) + "*"})
+	}
+	return policy
+}
+
+// LoadPolicy reads an allow/deny rule set from a YAML file, replacing
+// the built-in defaults entirely.
+func LoadPolicy(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := yaml.Unmarshal(raw, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Evaluate reports whether absPath is allowed under the policy, along
+// with the pattern that decided it ("" if no rule matched).
+func (p *Policy) Evaluate(absPath string) (allowed bool, matchedPattern string) {
+	for _, rule := range p.Rules {
+		if matchesPolicyPattern(rule.Pattern, absPath) {
+			return strings.EqualFold(rule.Action, "allow"), rule.Pattern
+		}
+	}
+	return true, ""
+}
+
+func matchesPolicyPattern(pattern, absPath string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		dir := strings.TrimSuffix(pattern, "*")
+		return absPath == dir || strings.HasPrefix(absPath, dir+string(filepath.Separator))
+	}
+	if absPath == pattern {
+		return true
+	}
+	matched, err := filepath.Match(pattern, absPath)
+	return err == nil && matched
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rscrim/data-redact/detector"
+)
+
+// DetectorHit is the number of matches one detector found in a file.
+type DetectorHit struct {
+	Detector string `json:"detector"`
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// FileResult captures the outcome of processing a single file, so
+// concurrent workers can report back to a shared ScanSummary.
+type FileResult struct {
+	Path          string        `json:"path"`
+	Mode          string        `json:"mode"`
+	BytesScanned  int           `json:"bytes_scanned"`
+	PIIHits       int           `json:"pii_hits"`
+	SPIIHits      int           `json:"spii_hits"`
+	Detectors     []DetectorHit `json:"detectors,omitempty"`
+	TouchedFields []string      `json:"touched_fields,omitempty"`
+	OutputFile    string        `json:"output_file,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// ScanSummary aggregates FileResults across an entire run.
+type ScanSummary struct {
+	FilesScanned int           `json:"files_scanned"`
+	BytesScanned int           `json:"bytes_scanned"`
+	PIIHits      int           `json:"pii_hits"`
+	SPIIHits     int           `json:"spii_hits"`
+	Files        []*FileResult `json:"files"`
+	Skipped      []SkipRecord  `json:"skipped,omitempty"`
+}
+
+// ScanOptions controls how scan walks and filters the target tree.
+type ScanOptions struct {
+	ModePtr        *string
+	OutputPtr      *string
+	Vault          *Vault
+	Workers        int
+	Includes       []string
+	Excludes       []string
+	Yes            bool
+	Policy         *Policy
+	FollowSymlinks bool
+	Detectors      []detector.Detector
+	Format         FormatOptions
+}
+
+// scan recursively walks root, feeding matching file paths to a pool of
+// Workers goroutines that call processFile concurrently. Results are
+// aggregated into a ScanSummary behind a mutex, since workers run in
+// parallel and must not race on shared counters.
+//
+// Symlinks are skipped (recorded as an audit SkipRecord) unless
+// opts.FollowSymlinks is set, permission errors are downgraded to
+// warnings instead of aborting the walk, and opts.Policy is evaluated
+// against every resolved absolute path to decide what gets traversed.
+func scan(root string, opts ScanOptions) (*ScanSummary, error) {
+	paths := make(chan string, 256)
+	walkErrCh := make(chan error, 1)
+	summary := &ScanSummary{}
+	var mu sync.Mutex
+
+	go func() {
+		defer close(paths)
+		walkErrCh <- walkEntry(root, opts, &mu, summary, paths)
+	}()
+
+	var wg sync.WaitGroup
+	var promptMu sync.Mutex
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if !opts.Yes {
+					promptMu.Lock()
+					approved := approveFile(path)
+					promptMu.Unlock()
+					if !approved {
+						continue
+					}
+				}
+				result := processFile(path, opts.ModePtr, opts.Vault, opts.OutputPtr, opts.Detectors, opts.Format)
+
+				mu.Lock()
+				summary.FilesScanned++
+				summary.BytesScanned += result.BytesScanned
+				summary.PIIHits += result.PIIHits
+				summary.SPIIHits += result.SPIIHits
+				summary.Files = append(summary.Files, result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summary, <-walkErrCh
+}
+
+// walkEntry visits path (a file or directory), applying the symlink,
+// policy and permission rules, and recurses into directories --
+// including symlinked directories when opts.FollowSymlinks is set, since
+// filepath.Walk never follows symlinks on its own and a plain recursive
+// walk would otherwise skip them. Matching files are sent to paths.
+func walkEntry(path string, opts ScanOptions, mu *sync.Mutex, summary *ScanSummary, paths chan<- string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsPermission(err) {
+			recordSkip(mu, summary, path, "permission denied")
+			return nil
+		}
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !opts.FollowSymlinks {
+			recordSkip(mu, summary, path, "symlink (use --follow-symlinks to traverse)")
+			return nil
+		}
+		target, err := os.Stat(path)
+		if err != nil {
+			recordSkip(mu, summary, path, "unresolvable symlink target")
+			return nil
+		}
+		info = target
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	if opts.Policy != nil {
+		if allowed, pattern := opts.Policy.Evaluate(absPath); !allowed {
+			recordSkip(mu, summary, path, fmt.Sprintf("denied by policy rule %q", pattern))
+			return nil
+		}
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			if os.IsPermission(err) {
+				recordSkip(mu, summary, path, "permission denied")
+				return nil
+			}
+			return err
+		}
+		for _, entry := range entries {
+			if err := walkEntry(filepath.Join(path, entry.Name()), opts, mu, summary, paths); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !matchesGlobs(path, opts.Includes, opts.Excludes) {
+		return nil
+	}
+	paths <- path
+	return nil
+}
+
+// matchesGlobs reports whether path's basename should be scanned, given
+// an include/exclude glob set. Excludes take precedence; an empty
+// include set matches everything not excluded.
+func matchesGlobs(path string, includes, excludes []string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range excludes {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return false
+		}
+	}
+	if len(includes) == 0 {
+		return true
+	}
+	for _, pattern := range includes {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSkip appends a SkipRecord to summary under mu and prints a
+// warning, since a skipped path is never fatal to the overall scan.
+func recordSkip(mu *sync.Mutex, summary *ScanSummary, path, reason string) {
+	mu.Lock()
+	summary.Skipped = append(summary.Skipped, SkipRecord{Path: path, Reason: reason})
+	mu.Unlock()
+	fmt.Printf("Warning: skipping %s (%s)\n", path, reason)
+}
+
+// splitCommaList parses a comma-separated glob list flag, ignoring blanks.
+func splitCommaList(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var patterns []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			patterns = append(patterns, part)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/rscrim/data-redact/detector"
+)
+
+// collectMatches runs every detector against input and returns all
+// matches sorted by start offset. Overlaps are resolved left to right:
+// a match that starts before the end of the last accepted one is
+// dropped, whichever detector found it. Only among matches with the
+// same start do earlier detectors in the slice take precedence.
+func collectMatches(input []byte, detectors []detector.Detector) []detector.Match {
+	var all []detector.Match
+	for _, d := range detectors {
+		all = append(all, d.Find(input)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Start < all[j].Start })
+
+	var filtered []detector.Match
+	lastEnd := -1
+	for _, m := range all {
+		if m.Start < lastEnd {
+			continue
+		}
+		filtered = append(filtered, m)
+		lastEnd = m.End
+	}
+	return filtered
+}
+
+// applySpans rewrites input by replacing each match's span with the
+// bytes replace returns for it, walking matches left to right.
+func applySpans(input []byte, matches []detector.Match, replace func(detector.Match) []byte) []byte {
+	if len(matches) == 0 {
+		return input
+	}
+	var out []byte
+	cursor := 0
+	for _, m := range matches {
+		out = append(out, input[cursor:m.Start]...)
+		out = append(out, replace(m)...)
+		cursor = m.End
+	}
+	out = append(out, input[cursor:]...)
+	return out
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/armon/go-radix"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	vaultSaltSize = 16
+
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
+// vaultEntry is the on-disk representation of one token <-> plaintext
+// mapping, keyed by the SHA-256 digest of the original value.
+type vaultEntry struct {
+	Token     string `json:"token"`
+	Plaintext string `json:"plaintext"`
+	Digest    string `json:"digest"`
+}
+
+// vaultFile is the JSON payload that gets AES-GCM encrypted on disk.
+type vaultFile struct {
+	Entries []vaultEntry `json:"entries"`
+}
+
+// Vault is a persistent, encrypted store that maps matched PII/SPII spans
+// to stable, reversible tokens. The digest-to-token map is saved with the
+// vault, so repeated tokenize runs over the same corpus mint the same
+// token for the same plaintext instead of drifting run to run. The
+// per-file radix index of digests lives in memory only and starts empty
+// on every Load.
+type Vault struct {
+	path       string
+	passphrase string
+	salt       []byte
+
+	mu        sync.Mutex
+	byDigest  map[string]string      // sha256 digest (hex) -> token
+	byToken   map[string]string      // token -> original plaintext
+	fileIndex map[string]*radix.Tree // redacted file path -> digest index
+}
+
+// NewVault returns a Vault backed by the sidecar file at path, encrypted
+// with a key derived from passphrase. Call Load before first use.
+func NewVault(path, passphrase string) *Vault {
+	return &Vault{
+		path:       path,
+		passphrase: passphrase,
+		byDigest:   make(map[string]string),
+		byToken:    make(map[string]string),
+		fileIndex:  make(map[string]*radix.Tree),
+	}
+}
+
+// Load reads and decrypts the vault sidecar, or initializes a fresh one
+// with a new salt if the sidecar does not exist yet.
+func (v *Vault) Load() error {
+	if _, err := os.Stat(v.path); os.IsNotExist(err) {
+		v.salt = make([]byte, vaultSaltSize)
+		if _, err := rand.Read(v.salt); err != nil {
+			return fmt.Errorf("generate vault salt: %w", err)
+		}
+		return nil
+	}
+
+	raw, err := os.ReadFile(v.path)
+	if err != nil {
+		return fmt.Errorf("read vault %s: %w", v.path, err)
+	}
+	if len(raw) < vaultSaltSize {
+		return fmt.Errorf("vault %s is truncated", v.path)
+	}
+	v.salt, raw = raw[:vaultSaltSize], raw[vaultSaltSize:]
+
+	key := deriveVaultKey(v.passphrase, v.salt)
+	plaintext, err := decryptAESGCM(key, raw)
+	if err != nil {
+		return fmt.Errorf("decrypt vault %s: %w", v.path, err)
+	}
+
+	var vf vaultFile
+	if err := json.Unmarshal(plaintext, &vf); err != nil {
+		return fmt.Errorf("parse vault %s: %w", v.path, err)
+	}
+	for _, entry := range vf.Entries {
+		v.byDigest[entry.Digest] = entry.Token
+		v.byToken[entry.Token] = entry.Plaintext
+	}
+	return nil
+}
+
+// Save encrypts and writes the vault back to its sidecar file.
+func (v *Vault) Save() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	vf := vaultFile{}
+	for digest, token := range v.byDigest {
+		vf.Entries = append(vf.Entries, vaultEntry{
+			Token:     token,
+			Plaintext: v.byToken[token],
+			Digest:    digest,
+		})
+	}
+
+	plaintext, err := json.Marshal(vf)
+	if err != nil {
+		return fmt.Errorf("marshal vault: %w", err)
+	}
+
+	key := deriveVaultKey(v.passphrase, v.salt)
+	ciphertext, err := encryptAESGCM(key, plaintext)
+	if err != nil {
+		return fmt.Errorf("encrypt vault: %w", err)
+	}
+
+	out := append(append([]byte{}, v.salt...), ciphertext...)
+	return os.WriteFile(v.path, out, 0600)
+}
+
+// TokenFor returns the stable token for value, minting one on first
+// sight. filePath identifies the redacted file's radix index; a digest
+// already indexed under that file is resolved straight from its tree,
+// and otherwise falls back to the global byDigest map, so repeated
+// tokenize runs over the same file (or across files) always resolve the
+// same plaintext to the same token.
+func (v *Vault) TokenFor(filePath string, value []byte) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	digest := sha256Hex(value)
+	tree := v.indexFor(filePath)
+
+	if cached, ok := tree.Get(digest); ok {
+		return cached.(string)
+	}
+
+	token, known := v.byDigest[digest]
+	if !known {
+		token = v.mintToken(digest)
+		v.byDigest[digest] = token
+		v.byToken[token] = string(value)
+	}
+	tree.Insert(digest, token)
+	return token
+}
+
+// Original returns the plaintext a token was minted for, if the vault
+// has seen it.
+func (v *Vault) Original(token string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	original, ok := v.byToken[token]
+	return original, ok
+}
+
+func (v *Vault) mintToken(digest string) string {
+	token := fmt.Sprintf("[TKN-%s]", digest[:8])
+	for {
+		if _, clash := v.byToken[token]; !clash {
+			return token
+		}
+		token = fmt.Sprintf("[TKN-%s]", randomHex(4))
+	}
+}
+
+func (v *Vault) indexFor(filePath string) *radix.Tree {
+	tree, ok := v.fileIndex[filePath]
+	if !ok {
+		tree = radix.New()
+		v.fileIndex[filePath] = tree
+	}
+	return tree
+}
+
+func sha256Hex(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func deriveVaultKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+}
+
+func encryptAESGCM(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptAESGCM(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("vault ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVaultTokenForIsStable(t *testing.T) {
+	v := NewVault(filepath.Join(t.TempDir(), "vault.dat"), "hunter2")
+	if err := v.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	first := v.TokenFor("a.txt", []byte("123-45-6789"))
+	second := v.TokenFor("a.txt", []byte("123-45-6789"))
+	if first != second {
+		t.Errorf("TokenFor minted different tokens for the same value: %q vs %q", first, second)
+	}
+
+	other := v.TokenFor("a.txt", []byte("987-65-4321"))
+	if other == first {
+		t.Errorf("TokenFor minted the same token for different values: %q", first)
+	}
+
+	// A re-run over a different file should still resolve to the same
+	// token for the same plaintext.
+	acrossFiles := v.TokenFor("b.txt", []byte("123-45-6789"))
+	if acrossFiles != first {
+		t.Errorf("TokenFor(%q) = %q, want %q (same plaintext across files)", "b.txt", acrossFiles, first)
+	}
+}
+
+func TestVaultOriginal(t *testing.T) {
+	v := NewVault(filepath.Join(t.TempDir(), "vault.dat"), "hunter2")
+	if err := v.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	token := v.TokenFor("a.txt", []byte("jane@example.com"))
+	original, ok := v.Original(token)
+	if !ok || original != "jane@example.com" {
+		t.Errorf("Original(%q) = %q, %v, want %q, true", token, original, ok, "jane@example.com")
+	}
+
+	if _, ok := v.Original("[TKN-deadbeef]"); ok {
+		t.Error("Original returned ok=true for a token the vault never minted")
+	}
+}
+
+func TestVaultSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.dat")
+
+	v := NewVault(path, "hunter2")
+	if err := v.Load(); err != nil {
+		t.Fatalf("Load (fresh): %v", err)
+	}
+	token := v.TokenFor("a.txt", []byte("123-45-6789"))
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded := NewVault(path, "hunter2")
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load (reloaded): %v", err)
+	}
+	original, ok := reloaded.Original(token)
+	if !ok || original != "123-45-6789" {
+		t.Errorf("Original(%q) after reload = %q, %v, want %q, true", token, original, ok, "123-45-6789")
+	}
+
+	// Tokenizing the same plaintext again after a reload must mint the
+	// same token, not a new one.
+	if again := reloaded.TokenFor("a.txt", []byte("123-45-6789")); again != token {
+		t.Errorf("TokenFor after reload = %q, want %q", again, token)
+	}
+}
+
+func TestVaultLoadWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.dat")
+
+	v := NewVault(path, "hunter2")
+	if err := v.Load(); err != nil {
+		t.Fatalf("Load (fresh): %v", err)
+	}
+	v.TokenFor("a.txt", []byte("123-45-6789"))
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	wrong := NewVault(path, "not-the-passphrase")
+	if err := wrong.Load(); err == nil {
+		t.Error("Load with the wrong passphrase succeeded, want an error")
+	}
+}